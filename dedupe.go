@@ -0,0 +1,86 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// dedupeMode controls how aggressively mergeNessusFiles drops ReportItems
+// it has already seen for a given host.
+type dedupeMode string
+
+const (
+	dedupeNone   dedupeMode = "none"   // keep every ReportItem, even exact repeats
+	dedupePlugin dedupeMode = "plugin" // drop later items with the same (PluginID, Port, Protocol)
+	dedupeFull   dedupeMode = "full"   // plugin, plus a hash of the canonicalized Content
+)
+
+// parseDedupeMode validates the --dedupe flag value.
+func parseDedupeMode(s string) (dedupeMode, error) {
+	switch dedupeMode(s) {
+	case "", dedupeNone:
+		return dedupeNone, nil
+	case dedupePlugin:
+		return dedupePlugin, nil
+	case dedupeFull:
+		return dedupeFull, nil
+	default:
+		return "", fmt.Errorf("unknown dedupe mode %q (want none, plugin, or full)", s)
+	}
+}
+
+// reportItemKey returns the dedupe key for item under mode. An empty key
+// means "don't dedupe this item" (mode is none).
+func reportItemKey(item ReportItem, mode dedupeMode) string {
+	switch mode {
+	case dedupePlugin:
+		return fmt.Sprintf("%s|%s|%s", item.PluginID, item.Port, item.Protocol)
+	case dedupeFull:
+		sum := sha256.Sum256([]byte(canonicalizeContent(item.Content)))
+		return fmt.Sprintf("%s|%s|%s|%x", item.PluginID, item.Port, item.Protocol, sum)
+	default:
+		return ""
+	}
+}
+
+// canonicalizeContent normalizes whitespace in a ReportItem's innerxml so
+// that cosmetic differences (indentation, trailing newlines) between two
+// copies of the same finding don't produce different hashes.
+func canonicalizeContent(content string) string {
+	return strings.Join(strings.Fields(content), " ")
+}
+
+// seenKeys registers items' dedupe keys without filtering anything; used
+// when a host is added for the first time so later duplicate scans of the
+// same host have something to compare against.
+func seenKeys(items []ReportItem, mode dedupeMode) map[string]struct{} {
+	keys := make(map[string]struct{}, len(items))
+	if mode == dedupeNone {
+		return keys
+	}
+	for _, item := range items {
+		keys[reportItemKey(item, mode)] = struct{}{}
+	}
+	return keys
+}
+
+// dedupeReportItems splits items into those not already present in seen and
+// a count of how many were suppressed as duplicates. seen is updated in
+// place with the keys of the items that were kept. When mode is
+// dedupeNone, every item is kept and seen is left untouched.
+func dedupeReportItems(items []ReportItem, seen map[string]struct{}, mode dedupeMode) (kept []ReportItem, suppressed int) {
+	if mode == dedupeNone {
+		return items, 0
+	}
+	for _, item := range items {
+		key := reportItemKey(item, mode)
+		if _, dup := seen[key]; dup {
+			suppressed++
+			continue
+		}
+		seen[key] = struct{}{}
+		kept = append(kept, item)
+	}
+	return kept, suppressed
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// filterConfig holds the --min-severity, --exclude-plugin, and
+// --include-family criteria applied to ReportItems after dedupe.
+type filterConfig struct {
+	minSeverity     int
+	excludePlugins  map[string]struct{}
+	includeFamilies map[string]struct{} // empty means every family is allowed
+}
+
+// newFilterConfig builds a filterConfig from comma-separated CLI values.
+func newFilterConfig(minSeverity int, excludePluginCSV, includeFamilyCSV string) filterConfig {
+	return filterConfig{
+		minSeverity:     minSeverity,
+		excludePlugins:  toSet(excludePluginCSV),
+		includeFamilies: toSet(includeFamilyCSV),
+	}
+}
+
+// toSet splits a comma-separated flag value into a lookup set, trimming
+// stray whitespace around each entry.
+func toSet(csv string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, v := range strings.Split(csv, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			set[v] = struct{}{}
+		}
+	}
+	return set
+}
+
+// keep reports whether item survives this filterConfig.
+func (f filterConfig) keep(item ReportItem) bool {
+	if f.minSeverity > 0 {
+		sev, err := strconv.Atoi(item.Severity)
+		if err == nil && sev < f.minSeverity {
+			return false
+		}
+	}
+	if _, excluded := f.excludePlugins[item.PluginID]; excluded {
+		return false
+	}
+	if len(f.includeFamilies) > 0 {
+		if _, ok := f.includeFamilies[item.PluginFamily]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// filterReportItems splits items into those that pass cfg and a count of
+// how many were filtered out.
+func filterReportItems(items []ReportItem, cfg filterConfig) (kept []ReportItem, filtered int) {
+	for _, item := range items {
+		if cfg.keep(item) {
+			kept = append(kept, item)
+		} else {
+			filtered++
+		}
+	}
+	return kept, filtered
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// logRecord is one structured entry in the merge audit trail: a file
+// being opened, a host being added or merged, or the final summary.
+// Not every field applies to every event; unused fields are left zero.
+type logRecord struct {
+	Level string    // "info" unless something went wrong
+	Time  time.Time // when the event happened
+	Event string    // "file_open", "host_added", "host_merged", "summary"
+	Node  string    // host name, when the event concerns one
+	Pkt   string    // source .nessus file the event came from
+	Size  int       // findings added by this event
+	Dup   int       // duplicate findings suppressed by this event
+	Filt  int       // findings dropped by severity/plugin/family filters
+}
+
+// logWriter emits logRecords in a particular serialization. Implementations
+// must be safe for concurrent use, since file-parsing workers and the
+// merger goroutine can all log at once.
+type logWriter interface {
+	Write(logRecord) error
+	Close() error
+}
+
+// newLogWriter builds the logWriter for the given --log-format value.
+func newLogWriter(format string, w io.Writer) (logWriter, error) {
+	switch format {
+	case "", "text":
+		return &textLogWriter{w: w}, nil
+	case "json":
+		return &jsonLogWriter{w: w, enc: json.NewEncoder(w)}, nil
+	case "recfile":
+		return &recfileLogWriter{w: w}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q (want text, json, or recfile)", format)
+	}
+}
+
+// textLogWriter writes one human-readable line per record.
+type textLogWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (t *textLogWriter) Write(r logRecord) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err := fmt.Fprintf(t.w, "%s [%s] %s", r.Time.Format(time.RFC3339), r.Level, r.Event)
+	if err != nil {
+		return err
+	}
+	if r.Pkt != "" {
+		fmt.Fprintf(t.w, " pkt=%s", r.Pkt)
+	}
+	if r.Node != "" {
+		fmt.Fprintf(t.w, " node=%s", r.Node)
+	}
+	if r.Size != 0 {
+		fmt.Fprintf(t.w, " size=%d", r.Size)
+	}
+	if r.Dup != 0 {
+		fmt.Fprintf(t.w, " dup=%d", r.Dup)
+	}
+	if r.Filt != 0 {
+		fmt.Fprintf(t.w, " filt=%d", r.Filt)
+	}
+	_, err = fmt.Fprintln(t.w)
+	return err
+}
+
+func (t *textLogWriter) Close() error { return nil }
+
+// jsonLogWriter writes newline-delimited JSON objects, one per record.
+type jsonLogWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	enc *json.Encoder
+}
+
+func (j *jsonLogWriter) Write(r logRecord) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.enc.Encode(r)
+}
+
+func (j *jsonLogWriter) Close() error { return nil }
+
+// recfileLogWriter writes GNU recutils-style stanzas: one "Field: value"
+// line per field, with a blank line between records, so the output can be
+// post-processed with recsel/recfmt.
+type recfileLogWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (r *recfileLogWriter) Write(rec logRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"Level", rec.Level},
+		{"Time", rec.Time.Format(time.RFC3339)},
+		{"Event", rec.Event},
+	}
+	if rec.Node != "" {
+		fields = append(fields, struct{ name, value string }{"Node", rec.Node})
+	}
+	if rec.Pkt != "" {
+		fields = append(fields, struct{ name, value string }{"Pkt", rec.Pkt})
+	}
+	if rec.Size != 0 {
+		fields = append(fields, struct{ name, value string }{"Size", fmt.Sprintf("%d", rec.Size)})
+	}
+	if rec.Dup != 0 {
+		fields = append(fields, struct{ name, value string }{"Dup", fmt.Sprintf("%d", rec.Dup)})
+	}
+	if rec.Filt != 0 {
+		fields = append(fields, struct{ name, value string }{"Filt", fmt.Sprintf("%d", rec.Filt)})
+	}
+
+	for _, f := range fields {
+		if _, err := fmt.Fprintf(r.w, "%s: %s\n", f.name, f.value); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(r.w)
+	return err
+}
+
+func (r *recfileLogWriter) Close() error { return nil }
@@ -1,76 +1,71 @@
 package main
 
 import (
-	"encoding/xml"
+	"context"
+	"flag"
 	"fmt"
-	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
 	"strings"
-
-	"github.com/schollz/progressbar/v3"
+	"time"
 )
 
-// NessusClientData represents the root element of a Nessus XML file
-type NessusClientData struct {
-	XMLName xml.Name `xml:"NessusClientData_v2"`
-	Policy  Policy   `xml:"Policy"`
-	Report  Report   `xml:"Report"`
-}
-
-// Policy represents the policy section
-type Policy struct {
-	XMLName xml.Name `xml:"Policy"`
-	Content string   `xml:",innerxml"`
-}
+func main() {
+	workers := flag.Int("workers", runtime.NumCPU(), "number of files to parse concurrently")
+	logFormat := flag.String("log-format", "text", "audit log format: text, json, or recfile")
+	dedupeFlag := flag.String("dedupe", "none", "duplicate ReportItem handling: none, plugin, or full")
+	partialOnAbort := flag.Bool("partial-on-abort", false, "on SIGINT, write whatever was merged so far instead of discarding it")
+	minSeverity := flag.Int("min-severity", 0, "drop ReportItems below this severity (0=Info .. 4=Critical)")
+	excludePlugin := flag.String("exclude-plugin", "", "comma-separated plugin IDs to drop")
+	includeFamily := flag.String("include-family", "", "comma-separated plugin families to keep (default: all)")
+	splitBySeverityFlag := flag.Bool("split-by-severity", false, "also write one merged_<severity>.nessus file per severity level")
+	reportFlag := flag.String("report", "", "comma-separated side-report formats to also generate: csv, json, md")
+	flag.Usage = func() {
+		fmt.Println("Usage: nessusmerger [flags] <input_directory> [output_file]")
+		fmt.Println("  input_directory: Directory containing .nessus files to merge")
+		fmt.Println("  output_file: Output merged file (default: merged_nessus_report.nessus)")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
 
-// Report represents the report section
-type Report struct {
-	XMLName     xml.Name     `xml:"Report"`
-	Name        string       `xml:"name,attr"`
-	ReportHosts []ReportHost `xml:"ReportHost"`
-}
+	args := flag.Args()
+	if len(args) < 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
 
-// ReportHost represents a single host
-type ReportHost struct {
-	XMLName        xml.Name       `xml:"ReportHost"`
-	Name           string         `xml:"name,attr"`
-	HostProperties HostProperties `xml:"HostProperties"`
-	ReportItems    []ReportItem   `xml:"ReportItem"`
-}
+	inputDir := args[0]
+	outputFile := "merged_nessus_report.nessus"
+	if len(args) > 1 {
+		outputFile = args[1]
+	}
 
-// HostProperties represents host properties
-type HostProperties struct {
-	XMLName xml.Name `xml:"HostProperties"`
-	Content string   `xml:",innerxml"`
-}
+	if *workers < 1 {
+		fmt.Println("Error: -workers must be at least 1")
+		os.Exit(1)
+	}
 
-// ReportItem represents a single finding/vulnerability
-type ReportItem struct {
-	XMLName      xml.Name `xml:"ReportItem"`
-	Content      string   `xml:",innerxml"`
-	Port         string   `xml:"port,attr"`
-	SvcName      string   `xml:"svc_name,attr"`
-	Protocol     string   `xml:"protocol,attr"`
-	Severity     string   `xml:"severity,attr"`
-	PluginID     string   `xml:"pluginID,attr"`
-	PluginName   string   `xml:"pluginName,attr"`
-	PluginFamily string   `xml:"pluginFamily,attr"`
-}
+	logger, err := newLogWriter(*logFormat, os.Stderr)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Close()
 
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: nessusmerger <input_directory> [output_file]")
-		fmt.Println("  input_directory: Directory containing .nessus files to merge")
-		fmt.Println("  output_file: Output merged file (default: merged_nessus_report.nessus)")
+	dedupe, err := parseDedupeMode(*dedupeFlag)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	inputDir := os.Args[1]
-	outputFile := "merged_nessus_report.nessus"
+	filter := newFilterConfig(*minSeverity, *excludePlugin, *includeFamily)
 
-	if len(os.Args) > 2 {
-		outputFile = os.Args[2]
+	reportFormats, err := parseReportFormats(*reportFlag)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
 	}
 
 	// Find all .nessus files in the input directory
@@ -89,30 +84,51 @@ func main() {
 	for _, file := range nessusFiles {
 		fmt.Printf("  - %s\n", file)
 	}
+	fmt.Println()
 
-	// Count total hosts across all files for progress tracking
-	fmt.Println("\nScanning files for total host count...")
-	totalHosts, err := countTotalHosts(nessusFiles)
-	if err != nil {
-		fmt.Printf("Error counting hosts: %v\n", err)
-		os.Exit(1)
-	}
-	fmt.Printf("Total hosts to process: %d\n\n", totalHosts)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
 	// Merge the Nessus files with progress tracking
-	mergedData, err := mergeNessusFiles(nessusFiles, totalHosts)
+	merged, err := mergeNessusFiles(ctx, nessusFiles, *workers, logger, dedupe, filter, *splitBySeverityFlag)
 	if err != nil {
 		fmt.Printf("Error merging Nessus files: %v\n", err)
 		os.Exit(1)
 	}
 
+	if merged.Aborted && !*partialOnAbort {
+		fmt.Println("Merge interrupted; discarding partial output (pass -partial-on-abort to keep it)")
+		os.Exit(130)
+	}
+
 	// Save the merged data
-	err = saveMergedReport(mergedData, outputFile)
+	err = saveMergedReport(merged.Data, outputFile)
 	if err != nil {
 		fmt.Printf("Error saving merged report: %v\n", err)
 		os.Exit(1)
 	}
 
+	for suffix, data := range merged.SplitDocs {
+		splitFile := severitySplitFilename(outputFile, suffix)
+		if err := saveMergedReport(data, splitFile); err != nil {
+			fmt.Printf("Error saving %s severity split: %v\n", suffix, err)
+			os.Exit(1)
+		}
+		fmt.Printf("  wrote %s\n", splitFile)
+	}
+
+	if len(reportFormats) > 0 {
+		if err := generateReports(merged.Hosts, reportFormats, outputFile, time.Now()); err != nil {
+			fmt.Printf("Error generating reports: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if merged.Aborted {
+		fmt.Printf("\n! Merge interrupted; wrote partial results for %d files into %s\n", len(nessusFiles), outputFile)
+		os.Exit(130)
+	}
+
 	fmt.Printf("\n✓ Successfully merged %d files into %s\n", len(nessusFiles), outputFile)
 }
 
@@ -134,163 +150,3 @@ func findNessusFiles(dir string) ([]string, error) {
 
 	return nessusFiles, err
 }
-
-// countTotalHosts counts the total number of hosts across all Nessus files
-func countTotalHosts(files []string) (int, error) {
-	totalCount := 0
-	for _, file := range files {
-		data, err := parseNessusFile(file)
-		if err != nil {
-			return 0, fmt.Errorf("error parsing %s: %v", file, err)
-		}
-		totalCount += len(data.Report.ReportHosts)
-	}
-	return totalCount, nil
-}
-
-// mergeNessusFiles merges multiple Nessus XML files into one with findings combination
-func mergeNessusFiles(files []string, totalHosts int) ([]byte, error) {
-	var basePolicy Policy
-	hostMap := make(map[string]*ReportHost) // Track hosts by name and merge findings
-
-	// Create progress bar
-	bar := progressbar.NewOptions(totalHosts,
-		progressbar.OptionSetDescription("Processing hosts"),
-		progressbar.OptionSetWidth(30), // Reduced from 50 to 30
-		progressbar.OptionShowCount(),
-		progressbar.OptionShowIts(),
-		progressbar.OptionSetItsString("hosts"),
-		progressbar.OptionSetTheme(progressbar.Theme{
-			SaucerHead:    ">>",
-			Saucer:        "=",
-			SaucerPadding: " ",
-			BarStart:      "[",
-			BarEnd:        "]",
-		}),
-		progressbar.OptionOnCompletion(func() {
-			fmt.Printf("\n")
-		}),
-	)
-
-	processedHosts := 0
-	uniqueHosts := 0
-	mergedHosts := 0
-	totalFindings := 0
-
-	for i, file := range files {
-		data, err := parseNessusFile(file)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing %s: %v", file, err)
-		}
-
-		if i == 0 {
-			// First file provides the policy template
-			basePolicy = data.Policy
-		}
-
-		// Process hosts from this file
-		for _, host := range data.Report.ReportHosts {
-			processedHosts++
-
-			if existingHost, exists := hostMap[host.Name]; !exists {
-				// New host - add it directly
-				hostCopy := host
-				hostMap[host.Name] = &hostCopy
-				uniqueHosts++
-				totalFindings += len(host.ReportItems)
-			} else {
-				// Duplicate host - merge findings
-				existingHost.ReportItems = append(existingHost.ReportItems, host.ReportItems...)
-				mergedHosts++
-				totalFindings += len(host.ReportItems)
-			}
-
-			// Update progress bar
-			bar.Describe(fmt.Sprintf("Processing %s | U:%d M:%d F:%d",
-				filepath.Base(file), uniqueHosts, mergedHosts, totalFindings))
-			bar.Add(1)
-		}
-	}
-
-	// Complete the progress bar
-	bar.Finish()
-
-	// Convert map to slice
-	var allHosts []ReportHost
-	for _, host := range hostMap {
-		allHosts = append(allHosts, *host)
-	}
-
-	// Build the merged XML
-	mergedData := NessusClientData{
-		Policy: basePolicy,
-		Report: Report{
-			Name:        "Merged Nessus Report",
-			ReportHosts: allHosts,
-		},
-	}
-
-	// Marshal to XML
-	output, err := xml.MarshalIndent(&mergedData, "", "  ")
-	if err != nil {
-		return nil, fmt.Errorf("error marshaling XML: %v", err)
-	}
-
-	// Add XML declaration
-	result := []byte(xml.Header + string(output))
-
-	fmt.Printf("\n✓ Merge complete!\n")
-	fmt.Printf("  Total hosts processed: %d\n", processedHosts)
-	fmt.Printf("  Unique hosts: %d\n", uniqueHosts)
-	fmt.Printf("  Hosts with merged findings: %d\n", mergedHosts)
-	fmt.Printf("  Total findings: %d\n", totalFindings)
-	return result, nil
-}
-
-// parseNessusFile parses a single Nessus XML file using the proper structure
-func parseNessusFile(filename string) (*NessusClientData, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	content, err := io.ReadAll(file)
-	if err != nil {
-		return nil, err
-	}
-
-	var data NessusClientData
-	err = xml.Unmarshal(content, &data)
-	if err != nil {
-		return nil, err
-	}
-
-	return &data, nil
-}
-
-// saveMergedReport saves the merged Nessus data to an XML file
-func saveMergedReport(data []byte, filename string) error {
-	// Ensure output directory exists
-	dir := filepath.Dir(filename)
-	if dir != "." {
-		err := os.MkdirAll(dir, 0755)
-		if err != nil {
-			return err
-		}
-	}
-
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Write the merged XML data directly
-	_, err = file.Write(data)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// fileJob pairs a file path with its position in the input list so the
+// worker pool can still identify "the first file" for the policy
+// template even though files are parsed out of order.
+type fileJob struct {
+	index int
+	path  string
+}
+
+// mergeResult is everything mergeNessusFiles produces: the combined merged
+// document, the optional per-severity splits, the merged hosts themselves
+// (for --report side-outputs), and whether the merge was interrupted.
+type mergeResult struct {
+	Data      []byte
+	SplitDocs map[string][]byte
+	Hosts     []ReportHost
+	Aborted   bool
+}
+
+// mergeNessusFiles merges multiple Nessus XML files into one with findings
+// combination. Files are parsed concurrently by a pool of workers goroutines;
+// each streams its ReportHosts onto a shared channel consumed by this
+// function, which is the sole owner of hostMap. Every file open, host add,
+// host merge, and the final summary are recorded on logger for the audit
+// trail. dedupe controls whether re-seen ReportItems on a host are dropped;
+// filter is applied afterwards to strip items by severity, plugin ID, or
+// family. If ctx is canceled (e.g. by a SIGINT), parsing winds down at the
+// next host boundary and mergeNessusFiles returns a result with
+// Aborted=true and whatever was merged so far, instead of an error. When
+// splitBySeverityOut is true, the per-severity documents are also
+// returned, keyed by file suffix ("crit", "high", ...).
+func mergeNessusFiles(ctx context.Context, files []string, workers int, logger logWriter, dedupe dedupeMode, filter filterConfig, splitBySeverityOut bool) (mergeResult, error) {
+	var basePolicy Policy
+	hostMap := make(map[string]*ReportHost)            // Track hosts by name and merge findings
+	seenByHost := make(map[string]map[string]struct{}) // dedupe keys already kept per host
+
+	jobs := make(chan fileJob)
+	hostsCh := make(chan parsedHost, 256)
+	errCh := make(chan error, 1)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				policy, err := parseNessusFileStream(ctx, job.path, hostsCh, logger)
+				if err != nil {
+					select {
+					case errCh <- err:
+					default:
+					}
+					return
+				}
+				if job.index == 0 {
+					basePolicy = policy
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i, file := range files {
+			select {
+			case jobs <- fileJob{index: i, path: file}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(hostsCh)
+	}()
+
+	// Indeterminate bar: without a pre-scan pass we don't know the total
+	// host count up front, so it's driven purely by Add(1) per host.
+	bar := progressbar.NewOptions(-1,
+		progressbar.OptionSetDescription("Processing hosts"),
+		progressbar.OptionSetWidth(30),
+		progressbar.OptionShowCount(),
+		progressbar.OptionShowIts(),
+		progressbar.OptionSetItsString("hosts"),
+		progressbar.OptionSetTheme(progressbar.Theme{
+			SaucerHead:    ">>",
+			Saucer:        "=",
+			SaucerPadding: " ",
+			BarStart:      "[",
+			BarEnd:        "]",
+		}),
+		progressbar.OptionOnCompletion(func() {
+			fmt.Printf("\n")
+		}),
+	)
+
+	processedHosts := 0
+	uniqueHosts := 0
+	mergedHosts := 0
+	totalFindings := 0
+	totalSuppressed := 0
+	totalFiltered := 0
+
+	for ph := range hostsCh {
+		host := ph.Host
+		processedHosts++
+
+		if existingHost, exists := hostMap[host.Name]; !exists {
+			// New host - dedupe is a no-op on first sight, but we still
+			// register its keys so later duplicate scans can be deduped
+			// against it, and run it through the severity/plugin filter.
+			seenByHost[host.Name] = seenKeys(host.ReportItems, dedupe)
+			filtered, filteredOut := filterReportItems(host.ReportItems, filter)
+
+			hostCopy := host
+			hostCopy.ReportItems = filtered
+			hostMap[host.Name] = &hostCopy
+
+			uniqueHosts++
+			totalFindings += len(filtered)
+			totalFiltered += filteredOut
+			logger.Write(logRecord{Level: "info", Time: time.Now(), Event: "host_added",
+				Node: host.Name, Pkt: ph.File, Size: len(filtered), Filt: filteredOut})
+		} else {
+			// Duplicate host - dedupe first, then filter the survivors.
+			deduped, suppressed := dedupeReportItems(host.ReportItems, seenByHost[host.Name], dedupe)
+			filtered, filteredOut := filterReportItems(deduped, filter)
+			existingHost.ReportItems = append(existingHost.ReportItems, filtered...)
+
+			mergedHosts++
+			totalFindings += len(filtered)
+			totalSuppressed += suppressed
+			totalFiltered += filteredOut
+			logger.Write(logRecord{Level: "info", Time: time.Now(), Event: "host_merged",
+				Node: host.Name, Pkt: ph.File, Size: len(filtered), Dup: suppressed, Filt: filteredOut})
+		}
+
+		bar.Describe(fmt.Sprintf("Processing hosts | U:%d M:%d F:%d Dup:%d Filt:%d",
+			uniqueHosts, mergedHosts, totalFindings, totalSuppressed, totalFiltered))
+		bar.Add(1)
+	}
+
+	bar.Finish()
+
+	aborted := ctx.Err() != nil
+
+	if parseErr := drainError(errCh); parseErr != nil {
+		return mergeResult{Aborted: aborted}, parseErr
+	}
+
+	// Convert map to slice
+	var allHosts []ReportHost
+	for _, host := range hostMap {
+		allHosts = append(allHosts, *host)
+	}
+
+	// Build the merged XML
+	mergedData := NessusClientData{
+		Policy: basePolicy,
+		Report: Report{
+			Name:        "Merged Nessus Report",
+			ReportHosts: allHosts,
+		},
+	}
+
+	// Marshal to XML
+	output, err := xml.MarshalIndent(&mergedData, "", "  ")
+	if err != nil {
+		return mergeResult{Aborted: aborted}, fmt.Errorf("error marshaling XML: %v", err)
+	}
+
+	result := mergeResult{
+		Data:    []byte(xml.Header + string(output)), // Add XML declaration
+		Hosts:   allHosts,
+		Aborted: aborted,
+	}
+
+	if splitBySeverityOut {
+		result.SplitDocs, err = splitBySeverity(allHosts, basePolicy)
+		if err != nil {
+			return mergeResult{Aborted: aborted}, err
+		}
+	}
+
+	if aborted {
+		fmt.Printf("\n! Merge interrupted\n")
+	} else {
+		fmt.Printf("\n✓ Merge complete!\n")
+	}
+	fmt.Printf("  Total hosts processed: %d\n", processedHosts)
+	fmt.Printf("  Unique hosts: %d\n", uniqueHosts)
+	fmt.Printf("  Hosts with merged findings: %d\n", mergedHosts)
+	fmt.Printf("  Total findings: %d\n", totalFindings)
+	fmt.Printf("  Duplicate findings suppressed: %d\n", totalSuppressed)
+	fmt.Printf("  Findings filtered out: %d\n", totalFiltered)
+
+	summaryLevel := "info"
+	if aborted {
+		summaryLevel = "warn"
+	}
+	logger.Write(logRecord{Level: summaryLevel, Time: time.Now(), Event: "summary",
+		Size: totalFindings, Dup: totalSuppressed, Filt: totalFiltered})
+
+	return result, nil
+}
+
+// drainError returns the first error reported on errCh, if any, without
+// blocking when no worker ever reported one.
+func drainError(errCh <-chan error) error {
+	select {
+	case err := <-errCh:
+		return err
+	default:
+		return nil
+	}
+}
+
+// saveMergedReport saves the merged Nessus data to an XML file
+func saveMergedReport(data []byte, filename string) error {
+	// Ensure output directory exists
+	dir := filepath.Dir(filename)
+	if dir != "." {
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Write the merged XML data directly
+	_, err = file.Write(data)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// parsedHost pairs a decoded ReportHost with the file it came from, so a
+// single shared channel can still tell hosts from different source files
+// apart.
+type parsedHost struct {
+	Host ReportHost
+	File string
+}
+
+// parseNessusFileStream decodes filename as a token stream instead of
+// buffering the whole document in memory. It only materializes the
+// Policy and ReportHost subtrees it needs, sending each fully decoded
+// ReportHost on hosts as soon as its closing tag is reached. The caller
+// owns hosts and is responsible for draining it; parseNessusFileStream
+// never closes it, since it may be shared across several files being
+// parsed concurrently. Each file open is recorded on logger for the audit
+// trail. If ctx is canceled mid-file, parsing stops between hosts and
+// returns whatever was decoded so far with a nil error, so an interrupted
+// merge isn't treated as a parse failure.
+func parseNessusFileStream(ctx context.Context, filename string, hosts chan<- parsedHost, logger logWriter) (Policy, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Policy{}, err
+	}
+	defer file.Close()
+
+	logger.Write(logRecord{Level: "info", Time: time.Now(), Event: "file_open", Pkt: filename})
+
+	var policy Policy
+	decoder := xml.NewDecoder(file)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return policy, nil
+		default:
+		}
+
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return policy, fmt.Errorf("error decoding %s: %w", filename, err)
+		}
+
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch se.Name.Local {
+		case "Policy":
+			if err := decoder.DecodeElement(&policy, &se); err != nil {
+				return policy, fmt.Errorf("error decoding policy in %s: %w", filename, err)
+			}
+		case "ReportHost":
+			var host ReportHost
+			if err := decoder.DecodeElement(&host, &se); err != nil {
+				return policy, fmt.Errorf("error decoding a ReportHost in %s: %w", filename, err)
+			}
+			hosts <- parsedHost{Host: host, File: filename}
+		}
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// parseReportFormats validates the comma-separated --report flag value.
+func parseReportFormats(flagValue string) (map[string]bool, error) {
+	formats := make(map[string]bool)
+	for _, f := range strings.Split(flagValue, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		switch f {
+		case "csv", "json", "md":
+			formats[f] = true
+		default:
+			return nil, fmt.Errorf("unknown report format %q (want csv, json, or md)", f)
+		}
+	}
+	return formats, nil
+}
+
+// generateReports writes the requested side-output reports alongside
+// outputFile, e.g. "merged.nessus" + {"csv"} -> "merged.csv".
+func generateReports(hosts []ReportHost, formats map[string]bool, outputFile string, generatedAt time.Time) error {
+	base := strings.TrimSuffix(outputFile, filepath.Ext(outputFile))
+
+	if formats["csv"] {
+		if err := writeCSVReport(hosts, base+".csv"); err != nil {
+			return fmt.Errorf("error writing csv report: %w", err)
+		}
+	}
+	if formats["json"] {
+		if err := writeJSONReport(hosts, base+".json", generatedAt); err != nil {
+			return fmt.Errorf("error writing json report: %w", err)
+		}
+	}
+	if formats["md"] {
+		if err := writeMarkdownReport(hosts, base+".md", generatedAt); err != nil {
+			return fmt.Errorf("error writing markdown report: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeCSVReport writes one row per (host, port, pluginID, severity, pluginName).
+func writeCSVReport(hosts []ReportHost, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	if err := w.Write([]string{"Host", "Port", "PluginID", "Severity", "PluginName"}); err != nil {
+		return err
+	}
+	for _, host := range hosts {
+		for _, item := range host.ReportItems {
+			row := []string{host.Name, item.Port, item.PluginID, severityLabel(item.Severity), item.PluginName}
+			if err := w.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// jsonVuln is one entry in jsonReport.BadVulns.
+type jsonVuln struct {
+	Host       string `json:"Host"`
+	Port       string `json:"Port"`
+	PluginID   string `json:"PluginID"`
+	PluginName string `json:"PluginName"`
+	Severity   string `json:"Severity"`
+}
+
+// jsonHostSummary is one entry in jsonReport.Hosts.
+type jsonHostSummary struct {
+	Name     string `json:"Name"`
+	IP       string `json:"IP"`
+	FQDN     string `json:"FQDN"`
+	OS       string `json:"OS"`
+	Findings int    `json:"Findings"`
+}
+
+// jsonReport is the shape written by writeJSONReport.
+type jsonReport struct {
+	RegistryURL     string            `json:"RegistryURL"`
+	Date            string            `json:"Date"`
+	VulnsBySeverity map[string]int    `json:"VulnsBySeverity"`
+	BadVulns        []jsonVuln        `json:"BadVulns"`
+	Hosts           []jsonHostSummary `json:"Hosts"`
+}
+
+// writeJSONReport writes a document grouping vulns by severity with counts,
+// the Critical/High findings, and a per-host summary. Host IP/FQDN/OS come
+// from HostProperties.Tags().
+func writeJSONReport(hosts []ReportHost, path string, generatedAt time.Time) error {
+	report := jsonReport{
+		Date:            generatedAt.Format(time.RFC3339),
+		VulnsBySeverity: make(map[string]int),
+	}
+
+	for _, host := range hosts {
+		tags := host.HostProperties.Tags()
+		report.Hosts = append(report.Hosts, jsonHostSummary{
+			Name:     host.Name,
+			IP:       tags["host-ip"],
+			FQDN:     tags["host-fqdn"],
+			OS:       tags["operating-system"],
+			Findings: len(host.ReportItems),
+		})
+
+		for _, item := range host.ReportItems {
+			label := severityLabel(item.Severity)
+			report.VulnsBySeverity[label]++
+			if label == "Critical" || label == "High" {
+				report.BadVulns = append(report.BadVulns, jsonVuln{
+					Host: host.Name, Port: item.Port, PluginID: item.PluginID,
+					PluginName: item.PluginName, Severity: label,
+				})
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// writeMarkdownReport writes a severity-count table followed by one section
+// per host listing its findings.
+func writeMarkdownReport(hosts []ReportHost, path string, generatedAt time.Time) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Vulnerability Report\n\n")
+	fmt.Fprintf(&b, "Generated: %s\n\n", generatedAt.Format(time.RFC3339))
+
+	counts := make(map[string]int)
+	for _, host := range hosts {
+		for _, item := range host.ReportItems {
+			counts[severityLabel(item.Severity)]++
+		}
+	}
+
+	fmt.Fprintf(&b, "## Findings by Severity\n\n")
+	fmt.Fprintf(&b, "| Severity | Count |\n|---|---|\n")
+	for _, sev := range severityOrder {
+		if n := counts[sev.label]; n > 0 {
+			fmt.Fprintf(&b, "| %s | %d |\n", sev.label, n)
+		}
+	}
+	fmt.Fprintf(&b, "\n")
+
+	fmt.Fprintf(&b, "## Hosts\n\n")
+	for _, host := range hosts {
+		tags := host.HostProperties.Tags()
+		fmt.Fprintf(&b, "### %s\n\n", host.Name)
+		if ip := tags["host-ip"]; ip != "" {
+			fmt.Fprintf(&b, "- IP: %s\n", ip)
+		}
+		if fqdn := tags["host-fqdn"]; fqdn != "" {
+			fmt.Fprintf(&b, "- FQDN: %s\n", fqdn)
+		}
+		if os := tags["operating-system"]; os != "" {
+			fmt.Fprintf(&b, "- OS: %s\n", os)
+		}
+		fmt.Fprintf(&b, "\n| Port | Plugin ID | Severity | Plugin Name |\n|---|---|---|---|\n")
+		for _, item := range host.ReportItems {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", item.Port, item.PluginID, severityLabel(item.Severity), item.PluginName)
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// severityOrder lists the Nessus severity codes from most to least severe,
+// alongside the file-name suffix --split-by-severity uses for each and the
+// human-readable label the vulnerability reports use.
+var severityOrder = []struct {
+	code   string
+	suffix string
+	label  string
+}{
+	{"4", "crit", "Critical"},
+	{"3", "high", "High"},
+	{"2", "medium", "Medium"},
+	{"1", "low", "Low"},
+	{"0", "info", "Info"},
+}
+
+// severityLabel returns the human-readable name for a Nessus severity code,
+// or "Unknown" for anything outside the usual 0-4 range.
+func severityLabel(code string) string {
+	for _, sev := range severityOrder {
+		if sev.code == code {
+			return sev.label
+		}
+	}
+	return "Unknown"
+}
+
+// splitBySeverity re-marshals hosts into one merged document per severity
+// level, keeping only the ReportItems at that level and dropping hosts left
+// with none. It returns a map from file suffix (e.g. "crit") to the
+// marshaled document, covering only severities actually present.
+func splitBySeverity(hosts []ReportHost, policy Policy) (map[string][]byte, error) {
+	docs := make(map[string][]byte)
+
+	for _, sev := range severityOrder {
+		var filteredHosts []ReportHost
+		for _, host := range hosts {
+			var items []ReportItem
+			for _, item := range host.ReportItems {
+				if item.Severity == sev.code {
+					items = append(items, item)
+				}
+			}
+			if len(items) == 0 {
+				continue
+			}
+			hostCopy := host
+			hostCopy.ReportItems = items
+			filteredHosts = append(filteredHosts, hostCopy)
+		}
+
+		if len(filteredHosts) == 0 {
+			continue
+		}
+
+		data := NessusClientData{
+			Policy: policy,
+			Report: Report{
+				Name:        fmt.Sprintf("Merged Nessus Report (%s)", sev.suffix),
+				ReportHosts: filteredHosts,
+			},
+		}
+
+		output, err := xml.MarshalIndent(&data, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling %s severity split: %w", sev.suffix, err)
+		}
+		docs[sev.suffix] = []byte(xml.Header + string(output))
+	}
+
+	return docs, nil
+}
+
+// severitySplitFilename derives the per-severity output path from the main
+// output file, e.g. "merged.nessus" + "crit" -> "merged_crit.nessus".
+func severitySplitFilename(outputFile, suffix string) string {
+	ext := filepath.Ext(outputFile)
+	base := strings.TrimSuffix(outputFile, ext)
+	return fmt.Sprintf("%s_%s%s", base, suffix, ext)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// NessusClientData represents the root element of a Nessus XML file
+type NessusClientData struct {
+	XMLName xml.Name `xml:"NessusClientData_v2"`
+	Policy  Policy   `xml:"Policy"`
+	Report  Report   `xml:"Report"`
+}
+
+// Policy represents the policy section
+type Policy struct {
+	XMLName xml.Name `xml:"Policy"`
+	Content string   `xml:",innerxml"`
+}
+
+// Report represents the report section
+type Report struct {
+	XMLName     xml.Name     `xml:"Report"`
+	Name        string       `xml:"name,attr"`
+	ReportHosts []ReportHost `xml:"ReportHost"`
+}
+
+// ReportHost represents a single host
+type ReportHost struct {
+	XMLName        xml.Name       `xml:"ReportHost"`
+	Name           string         `xml:"name,attr"`
+	HostProperties HostProperties `xml:"HostProperties"`
+	ReportItems    []ReportItem   `xml:"ReportItem"`
+}
+
+// HostProperties represents host properties
+type HostProperties struct {
+	XMLName xml.Name `xml:"HostProperties"`
+	Content string   `xml:",innerxml"`
+}
+
+// Tags parses the <tag name="...">value</tag> children kept in Content
+// into a name->value map, e.g. Tags()["host-ip"]. Malformed content yields
+// an empty map rather than an error, since Tags is best-effort metadata
+// for reports, not something merge correctness depends on.
+func (h HostProperties) Tags() map[string]string {
+	var wrapper struct {
+		Tags []struct {
+			Name  string `xml:"name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"tag"`
+	}
+
+	tags := make(map[string]string)
+	if err := xml.Unmarshal([]byte("<tags>"+h.Content+"</tags>"), &wrapper); err != nil {
+		return tags
+	}
+	for _, t := range wrapper.Tags {
+		tags[t.Name] = strings.TrimSpace(t.Value)
+	}
+	return tags
+}
+
+// ReportItem represents a single finding/vulnerability
+type ReportItem struct {
+	XMLName      xml.Name `xml:"ReportItem"`
+	Content      string   `xml:",innerxml"`
+	Port         string   `xml:"port,attr"`
+	SvcName      string   `xml:"svc_name,attr"`
+	Protocol     string   `xml:"protocol,attr"`
+	Severity     string   `xml:"severity,attr"`
+	PluginID     string   `xml:"pluginID,attr"`
+	PluginName   string   `xml:"pluginName,attr"`
+	PluginFamily string   `xml:"pluginFamily,attr"`
+}